@@ -0,0 +1,35 @@
+// Package aws holds the types shared between the aws and openshift
+// BlobStorageProvider implementations so that a BlobStorage CR's output
+// Secret has the same shape regardless of which strategy provisioned it.
+package aws
+
+// Detail keys under which BlobStorageDeploymentDetails fields are written to
+// a BlobStorage CR's output Secret.
+const (
+	DetailsBlobStorageBucketName          = "bucketName"
+	DetailsBlobStorageBucketRegion        = "bucketRegion"
+	DetailsBlobStorageCredentialKeyID     = "credentialKeyID"
+	DetailsBlobStorageCredentialSecretKey = "credentialSecretKey"
+
+	// DetailsBlobStorageRoleARN and DetailsBlobStorageWebIdentityTokenFile
+	// are only populated in credentialModeSTS; the static-key fields above
+	// are left out of the Secret in that mode instead of being blanked.
+	DetailsBlobStorageRoleARN              = "roleARN"
+	DetailsBlobStorageWebIdentityTokenFile = "webIdentityTokenFile"
+)
+
+// BlobStorageDeploymentDetails describes the bucket a BlobStorageProvider
+// provisioned (or mirrored placeholders for), and the credentials a
+// consumer should use to reach it. Exactly one of the static-key pair
+// (CredentialKeyID/CredentialSecretKey) or the STS pair
+// (RoleARN/WebIdentityTokenFile) is populated, depending on the credential
+// mode the owning BlobStorage CR resolved to.
+type BlobStorageDeploymentDetails struct {
+	BucketName          string
+	BucketRegion        string
+	CredentialKeyID     string
+	CredentialSecretKey string
+
+	RoleARN              string
+	WebIdentityTokenFile string
+}
@@ -0,0 +1,51 @@
+// Package metrics holds the Prometheus collectors shared by the CRO
+// providers so that e.g. the aws and openshift BlobStorageProvider
+// implementations report reconcile health under the same metric names.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// BlobStorageReconcileTotal counts CreateStorage reconciles, labelled by
+	// provider, strategy and the resulting phase; GetReconcileTime calls are
+	// counted under the "scheduled" phase. DeleteStorage is not counted.
+	BlobStorageReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cro_blobstorage_reconcile_total",
+		Help: "Total number of BlobStorage reconciles, by provider, strategy and phase.",
+	}, []string{"provider", "strategy", "phase"})
+
+	// BlobStorageReconcileDuration tracks how long a BlobStorage reconcile
+	// took, labelled by provider and strategy.
+	BlobStorageReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cro_blobstorage_reconcile_duration_seconds",
+		Help:    "Duration in seconds of a BlobStorage reconcile.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider", "strategy"})
+
+	// BlobStorageSecretMissingFields counts how many output Secret fields
+	// a given BlobStorage CR's reconcile had to fall back to a placeholder
+	// for, labelled by the CR's namespace/name.
+	BlobStorageSecretMissingFields = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cro_blobstorage_secret_missing_fields",
+		Help: "Number of BlobStorage output Secret fields reset to a placeholder value, by CR.",
+	}, []string{"cr"})
+
+	// BlobStorageLastReconcileSuccess is the unix timestamp of the last
+	// successful BlobStorage reconcile, labelled by CR.
+	BlobStorageLastReconcileSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cro_blobstorage_last_reconcile_success_timestamp_seconds",
+		Help: "Unix timestamp of the last successful BlobStorage reconcile, by CR.",
+	}, []string{"cr"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		BlobStorageReconcileTotal,
+		BlobStorageReconcileDuration,
+		BlobStorageSecretMissingFields,
+		BlobStorageLastReconcileSuccess,
+	)
+}
@@ -0,0 +1,406 @@
+package openshift
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// openshiftStrategyConfigMapName holds the tunables for the in-cluster
+	// backend, keyed by CR namespace the same way the cloud strategy CMs are.
+	openshiftStrategyConfigMapName = "cloud-resources-openshift-strategies"
+	openshiftStrategyConfigMapKey  = "blobstorage"
+
+	defaultBackendImage   = "quay.io/minio/minio"
+	defaultBackendVersion = "RELEASE.2023-01-25T00-19-54Z"
+	defaultBackendPVCSize = "1Gi"
+
+	backendPort            = 9000
+	backendAccessKeyField  = "accesskey"
+	backendSecretKeyField  = "secretkey"
+	backendBootstrapSuffix = "-minio-creds"
+	backendBucketRegion    = "us-east-1"
+)
+
+// backendStrategy is the `blobstorage` entry of the openshift strategy
+// ConfigMap. DeployBackend defaults to false so existing CRs that never
+// configured it keep getting the placeholder-only behaviour.
+type backendStrategy struct {
+	DeployBackend bool   `json:"deployBackend"`
+	Image         string `json:"image"`
+	Version       string `json:"version"`
+	PVCSize       string `json:"pvcSize"`
+
+	// CredentialMode pins the credential mode (see credentials.go) for all
+	// BlobStorage CRs in the namespace this strategy config lives in.
+	CredentialMode string `json:"credentialMode"`
+	// RoleARN is the pre-provisioned IAM role STS-mode consumers assume.
+	// CRO does not create IAM roles itself.
+	RoleARN string `json:"roleArn"`
+}
+
+func (s *backendStrategy) applyDefaults() {
+	if s.Image == "" {
+		s.Image = defaultBackendImage
+	}
+	if s.Version == "" {
+		s.Version = defaultBackendVersion
+	}
+	if s.PVCSize == "" {
+		s.PVCSize = defaultBackendPVCSize
+	}
+}
+
+func (p BlobStorageProvider) readBackendStrategy(ctx context.Context, bs *v1alpha1.BlobStorage) (*backendStrategy, error) {
+	cm := &corev1.ConfigMap{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: openshiftStrategyConfigMapName, Namespace: bs.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return &backendStrategy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := cm.Data[openshiftStrategyConfigMapKey]
+	if !ok || raw == "" {
+		return &backendStrategy{}, nil
+	}
+
+	strategy := &backendStrategy{}
+	if err := json.Unmarshal([]byte(raw), strategy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal openshift blobstorage strategy: %w", err)
+	}
+	strategy.applyDefaults()
+	return strategy, nil
+}
+
+func backendName(bs *v1alpha1.BlobStorage) string {
+	return bs.Name + "-minio"
+}
+
+func backendBootstrapSecretName(bs *v1alpha1.BlobStorage) string {
+	return backendName(bs) + backendBootstrapSuffix
+}
+
+func backendBucketName(bs *v1alpha1.BlobStorage) string {
+	return bs.Namespace + "-" + bs.Name
+}
+
+func backendLabels(bs *v1alpha1.BlobStorage) map[string]string {
+	return map[string]string{"cro.integreatly.org/blobstorage-backend": backendName(bs)}
+}
+
+// createBackendStorage deploys (or reuses) an in-cluster MinIO StatefulSet
+// and Service, waits for it to become Ready, provisions a bucket on it and
+// writes the output Secret with the backend's real connection details.
+func (p BlobStorageProvider) createBackendStorage(ctx context.Context, bs *v1alpha1.BlobStorage, strategy *backendStrategy, mode string) (*providers.BlobStorageInstance, types.StatusMessage, error) {
+	bootstrap, err := p.reconcileBootstrapSecret(ctx, bs)
+	if err != nil {
+		return nil, "failed to reconcile minio bootstrap secret", err
+	}
+
+	if err := p.reconcileBackendService(ctx, bs); err != nil {
+		return nil, "failed to reconcile minio service", err
+	}
+
+	statefulSet, err := p.reconcileBackendStatefulSet(ctx, bs, strategy, bootstrap.Name)
+	if err != nil {
+		return nil, "failed to reconcile minio statefulset", err
+	}
+
+	if statefulSet.Status.ReadyReplicas < 1 {
+		return nil, "minio backend creation in progress", nil
+	}
+
+	accessKeyID := string(bootstrap.Data[backendAccessKeyField])
+	secretAccessKey := string(bootstrap.Data[backendSecretKeyField])
+	endpoint := fmt.Sprintf("%s.%s.svc:%d", backendName(bs), bs.Namespace, backendPort)
+	bucketName := backendBucketName(bs)
+
+	if err := p.bucketProvisioner().EnsureBucket(endpoint, accessKeyID, secretAccessKey, bucketName); err != nil {
+		return nil, "failed to provision minio bucket", err
+	}
+
+	name, namespace := p.secretRef(bs)
+
+	if mode == credentialModeSTS {
+		if err := p.reconcileServiceAccountForSTS(ctx, bs, strategy); err != nil {
+			return nil, "failed to reconcile blobstorage service account", err
+		}
+
+		existing := &corev1.Secret{}
+		err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, existing)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, "failed to get blobstorage secret", err
+		}
+		data := map[string][]byte{}
+		if err == nil {
+			data = existing.Data
+		}
+
+		roleARN := strategy.RoleARN
+		if roleARN == "" {
+			roleARN = p.placeholderOr(bs, data, aws.DetailsBlobStorageRoleARN)
+		}
+
+		details := &aws.BlobStorageDeploymentDetails{
+			BucketRegion:         backendBucketRegion,
+			RoleARN:              roleARN,
+			WebIdentityTokenFile: defaultWebIdentityTokenFile,
+		}
+		if err := p.reconcileSecret(ctx, bs, name, namespace, stsSecretData(roleARN, details.BucketRegion)); err != nil {
+			return nil, "failed to reconcile blobstorage secret", err
+		}
+		return &providers.BlobStorageInstance{DeploymentDetails: details}, "reconcile complete", nil
+	}
+
+	details := &aws.BlobStorageDeploymentDetails{
+		BucketName:          bucketName,
+		BucketRegion:        backendBucketRegion,
+		CredentialKeyID:     accessKeyID,
+		CredentialSecretKey: secretAccessKey,
+	}
+
+	if err := p.reconcileSecret(ctx, bs, name, namespace, detailsToSecretData(details)); err != nil {
+		return nil, "failed to reconcile blobstorage secret", err
+	}
+
+	return &providers.BlobStorageInstance{DeploymentDetails: details}, "reconcile complete", nil
+}
+
+// BucketProvisioner creates a bucket on the in-cluster backend. It is an
+// interface, rather than a direct minio-go call, purely so tests can stub it
+// out without standing up a real MinIO server.
+type BucketProvisioner interface {
+	EnsureBucket(endpoint, accessKeyID, secretAccessKey, bucketName string) error
+}
+
+type minioBucketProvisioner struct{}
+
+func (minioBucketProvisioner) EnsureBucket(endpoint, accessKeyID, secretAccessKey, bucketName string) error {
+	mc, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: false,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	exists, err := mc.BucketExists(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	return mc.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{Region: backendBucketRegion})
+}
+
+func (p BlobStorageProvider) bucketProvisioner() BucketProvisioner {
+	if p.BucketProvisioner != nil {
+		return p.BucketProvisioner
+	}
+	return minioBucketProvisioner{}
+}
+
+func (p BlobStorageProvider) reconcileBootstrapSecret(ctx context.Context, bs *v1alpha1.BlobStorage) (*corev1.Secret, error) {
+	name := backendBootstrapSecretName(bs)
+	existing := &corev1.Secret{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: bs.Namespace}, existing)
+	if err == nil {
+		return existing, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	accessKeyID, err := randomHex(10)
+	if err != nil {
+		return nil, err
+	}
+	secretAccessKey, err := randomHex(20)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: bs.Namespace,
+			Labels:    backendLabels(bs),
+		},
+		Data: map[string][]byte{
+			backendAccessKeyField: []byte(accessKeyID),
+			backendSecretKeyField: []byte(secretAccessKey),
+		},
+	}
+	if err := p.Client.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (p BlobStorageProvider) reconcileBackendService(ctx context.Context, bs *v1alpha1.BlobStorage) error {
+	svc := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backendName(bs),
+			Namespace: bs.Namespace,
+			Labels:    backendLabels(bs),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: backendLabels(bs),
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "api",
+					Port:       backendPort,
+					TargetPort: intstr.FromInt(backendPort),
+				},
+			},
+		},
+	}
+
+	existing := &corev1.Service{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: svc.Name, Namespace: svc.Namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		return p.Client.Create(ctx, svc)
+	}
+	return err
+}
+
+func (p BlobStorageProvider) reconcileBackendStatefulSet(ctx context.Context, bs *v1alpha1.BlobStorage, strategy *backendStrategy, bootstrapSecretName string) (*appsv1.StatefulSet, error) {
+	pvcSize, err := resource.ParseQuantity(strategy.PVCSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openshift blobstorage strategy pvcSize %q: %w", strategy.PVCSize, err)
+	}
+
+	labels := backendLabels(bs)
+	replicas := int32(1)
+
+	desired := &appsv1.StatefulSet{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backendName(bs),
+			Namespace: bs.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: backendName(bs),
+			Replicas:    &replicas,
+			Selector:    &v1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "minio",
+							Image:   fmt.Sprintf("%s:%s", strategy.Image, strategy.Version),
+							Command: []string{"minio", "server", "/data"},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: backendPort},
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "MINIO_ROOT_USER",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: bootstrapSecretName},
+											Key:                  backendAccessKeyField,
+										},
+									},
+								},
+								{
+									Name: "MINIO_ROOT_PASSWORD",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: bootstrapSecretName},
+											Key:                  backendSecretKeyField,
+										},
+									},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: "/data"},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: v1.ObjectMeta{Name: "data"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: pvcSize,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	existing := &appsv1.StatefulSet{}
+	err = p.Client.Get(ctx, client.ObjectKey{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		if err := p.Client.Create(ctx, desired); err != nil {
+			return nil, err
+		}
+		return desired, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// deleteBackend removes the StatefulSet, Service and bootstrap Secret
+// created by createBackendStorage. The provisioned bucket itself is left in
+// place, matching the "don't delete customer data" convention the cloud
+// providers follow for their equivalent teardown paths.
+func (p BlobStorageProvider) deleteBackend(ctx context.Context, bs *v1alpha1.BlobStorage) error {
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: v1.ObjectMeta{Name: backendName(bs), Namespace: bs.Namespace}}
+	if err := p.Client.Delete(ctx, statefulSet); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	svc := &corev1.Service{ObjectMeta: v1.ObjectMeta{Name: backendName(bs), Namespace: bs.Namespace}}
+	if err := p.Client.Delete(ctx, svc); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	bootstrap := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: backendBootstrapSecretName(bs), Namespace: bs.Namespace}}
+	if err := p.Client.Delete(ctx, bootstrap); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// credentialModeStatic is the default: the output Secret carries a
+	// static access key ID/secret access key pair.
+	credentialModeStatic = "static"
+
+	// credentialModeSTS opts the CR into IRSA/Workload Identity style
+	// credentials: the output Secret only carries a role ARN and the path
+	// to a projected web identity token, and a ServiceAccount is
+	// annotated so the pod consuming it can assume that role.
+	credentialModeSTS = "sts"
+
+	defaultWebIdentityTokenFile = "/var/run/secrets/openshift/serviceaccount/token"
+	roleARNAnnotation           = "eks.amazonaws.com/role-arn"
+)
+
+// resolveCredentialMode decides which credential mode a BlobStorage CR
+// should use. The mode can be pinned cluster-wide via the openshift
+// strategy config, requested per-CR via BlobStorageSpec.CredentialMode, or
+// both - in which case they must agree, otherwise reconciliation is
+// rejected rather than silently picking one.
+func (p BlobStorageProvider) resolveCredentialMode(bs *v1alpha1.BlobStorage, strategy *backendStrategy) (string, error) {
+	crMode := bs.Spec.CredentialMode
+	cmMode := strategy.CredentialMode
+
+	if crMode != "" && cmMode != "" && crMode != cmMode {
+		return "", fmt.Errorf("blobstorage %s/%s requests credential mode %q but the openshift strategy config pins %q", bs.Namespace, bs.Name, crMode, cmMode)
+	}
+
+	mode := cmMode
+	if crMode != "" {
+		mode = crMode
+	}
+	if mode == "" {
+		mode = credentialModeStatic
+	}
+
+	if mode != credentialModeStatic && mode != credentialModeSTS {
+		return "", fmt.Errorf("unsupported blobstorage credential mode %q", mode)
+	}
+	return mode, nil
+}
+
+// stsSecretData builds the output Secret contents for credentialModeSTS:
+// only the role ARN, the web identity token file path and the bucket
+// region are written; the static-key fields are intentionally absent.
+func stsSecretData(roleARN, bucketRegion string) map[string][]byte {
+	return map[string][]byte{
+		aws.DetailsBlobStorageRoleARN:              []byte(roleARN),
+		aws.DetailsBlobStorageWebIdentityTokenFile: []byte(defaultWebIdentityTokenFile),
+		aws.DetailsBlobStorageBucketRegion:         []byte(bucketRegion),
+	}
+}
+
+// reconcileServiceAccountForSTS annotates the ServiceAccount consumers of
+// bs are expected to run as with the IAM role to assume, creating it if it
+// doesn't exist yet.
+func (p BlobStorageProvider) reconcileServiceAccountForSTS(ctx context.Context, bs *v1alpha1.BlobStorage, strategy *backendStrategy) error {
+	if strategy.RoleARN == "" {
+		// Nothing to annotate yet; CreateStorage still reports STS-shaped
+		// placeholders so callers can see the mode took effect.
+		return nil
+	}
+
+	name := bs.Name + "-blobstorage"
+	sa := &corev1.ServiceAccount{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: bs.Namespace}, sa)
+	if apierrors.IsNotFound(err) {
+		return p.Client.Create(ctx, &corev1.ServiceAccount{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: bs.Namespace,
+				Annotations: map[string]string{
+					roleARNAnnotation: strategy.RoleARN,
+				},
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if sa.Annotations[roleARNAnnotation] == strategy.RoleARN {
+		return nil
+	}
+	if sa.Annotations == nil {
+		sa.Annotations = map[string]string{}
+	}
+	sa.Annotations[roleARNAnnotation] = strategy.RoleARN
+	return p.Client.Update(ctx, sa)
+}
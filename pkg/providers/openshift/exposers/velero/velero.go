@@ -0,0 +1,119 @@
+// Package velero creates the Velero-native objects a BlobStorage CR needs to
+// be usable as a BackupStorageLocation, so that OpenShift users driving CRO
+// don't have to hand-wire a BSL and its credentials Secret once a bucket has
+// been provisioned.
+package velero
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ExposeAsName is the value BlobStorageSpec.ExposeAs entries must match
+	// to opt a BlobStorage CR into this exposer.
+	ExposeAsName = "velero"
+
+	// DefaultNamespace is used when the CR doesn't request a specific
+	// namespace for its BackupStorageLocation, matching the namespace the
+	// OADP operator installs Velero into by default.
+	DefaultNamespace = "openshift-adp"
+
+	credentialsSecretKey = "cloud"
+	bslProvider          = "aws"
+)
+
+// Reconcile creates or updates the BackupStorageLocation and credentials
+// Secret for bs in namespace, pointing Velero at the bucket described by
+// details. Existing objects are updated in place rather than duplicated.
+func Reconcile(ctx context.Context, c client.Client, bs *v1alpha1.BlobStorage, details *aws.BlobStorageDeploymentDetails, namespace string) error {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	secretName := credentialsSecretName(bs)
+	if err := reconcileCredentialsSecret(ctx, c, secretName, namespace, details); err != nil {
+		return fmt.Errorf("failed to reconcile velero credentials secret: %w", err)
+	}
+
+	if err := reconcileBackupStorageLocation(ctx, c, bs, details, secretName, namespace); err != nil {
+		return fmt.Errorf("failed to reconcile velero backupstoragelocation: %w", err)
+	}
+
+	return nil
+}
+
+func credentialsSecretName(bs *v1alpha1.BlobStorage) string {
+	return bs.Name + "-velero-creds"
+}
+
+func backupStorageLocationName(bs *v1alpha1.BlobStorage) string {
+	return bs.Namespace + "-" + bs.Name
+}
+
+func reconcileCredentialsSecret(ctx context.Context, c client.Client, name, namespace string, details *aws.BlobStorageDeploymentDetails) error {
+	data := map[string][]byte{
+		credentialsSecretKey: []byte(awsCredentialsFile(details.CredentialKeyID, details.CredentialSecretKey)),
+	}
+
+	existing := &corev1.Secret{}
+	err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		return c.Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       data,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.Data = data
+	return c.Update(ctx, existing)
+}
+
+func awsCredentialsFile(accessKeyID, secretAccessKey string) string {
+	return fmt.Sprintf("[default]\naws_access_key_id = %s\naws_secret_access_key = %s\n", accessKeyID, secretAccessKey)
+}
+
+func reconcileBackupStorageLocation(ctx context.Context, c client.Client, bs *v1alpha1.BlobStorage, details *aws.BlobStorageDeploymentDetails, credentialsSecret, namespace string) error {
+	desiredSpec := velerov1.BackupStorageLocationSpec{
+		Provider: bslProvider,
+		StorageType: velerov1.StorageType{
+			ObjectStorage: &velerov1.ObjectStorageLocation{
+				Bucket: details.BucketName,
+			},
+		},
+		Config: map[string]string{
+			"region": details.BucketRegion,
+		},
+		Credential: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: credentialsSecret},
+			Key:                  credentialsSecretKey,
+		},
+	}
+
+	name := backupStorageLocationName(bs)
+	existing := &velerov1.BackupStorageLocation{}
+	err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		return c.Create(ctx, &velerov1.BackupStorageLocation{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       desiredSpec,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.Spec = desiredSpec
+	return c.Update(ctx, existing)
+}
@@ -0,0 +1,98 @@
+package velero
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testBlobStorage() *v1alpha1.BlobStorage {
+	return &v1alpha1.BlobStorage{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+	}
+}
+
+func testDetails() *aws.BlobStorageDeploymentDetails {
+	return &aws.BlobStorageDeploymentDetails{
+		BucketName:          "test-bucket",
+		BucketRegion:        "us-east-1",
+		CredentialKeyID:     "access",
+		CredentialSecretKey: "secret",
+	}
+}
+
+func TestReconcile_CreatesBackupStorageLocation(t *testing.T) {
+	bs := testBlobStorage()
+	details := testDetails()
+	c := fake.NewFakeClient()
+
+	if err := Reconcile(context.TODO(), c, bs, details, "velero-ns"); err != nil {
+		t.Fatalf("Reconcile() unexpected error: %v", err)
+	}
+
+	bsl := &velerov1.BackupStorageLocation{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: backupStorageLocationName(bs), Namespace: "velero-ns"}, bsl); err != nil {
+		t.Fatalf("expected BackupStorageLocation to be created: %v", err)
+	}
+	if bsl.Spec.Provider != bslProvider {
+		t.Errorf("Provider = %v, want %v", bsl.Spec.Provider, bslProvider)
+	}
+	if bsl.Spec.ObjectStorage == nil || bsl.Spec.ObjectStorage.Bucket != details.BucketName {
+		t.Errorf("ObjectStorage.Bucket = %v, want %v", bsl.Spec.ObjectStorage, details.BucketName)
+	}
+	if bsl.Spec.Config["region"] != details.BucketRegion {
+		t.Errorf("Config[region] = %v, want %v", bsl.Spec.Config["region"], details.BucketRegion)
+	}
+	if bsl.Spec.Credential == nil || bsl.Spec.Credential.Key != credentialsSecretKey {
+		t.Errorf("Credential = %v, want key %v", bsl.Spec.Credential, credentialsSecretKey)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: credentialsSecretName(bs), Namespace: "velero-ns"}, secret); err != nil {
+		t.Fatalf("expected credentials secret to be created: %v", err)
+	}
+	if _, ok := secret.Data[credentialsSecretKey]; !ok {
+		t.Errorf("secret missing %q key", credentialsSecretKey)
+	}
+}
+
+func TestReconcile_UpdatesExistingBackupStorageLocationInPlace(t *testing.T) {
+	bs := testBlobStorage()
+	details := testDetails()
+
+	existing := &velerov1.BackupStorageLocation{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backupStorageLocationName(bs),
+			Namespace: "velero-ns",
+		},
+		Spec: velerov1.BackupStorageLocationSpec{
+			Provider: "stale",
+		},
+	}
+	c := fake.NewFakeClient(existing)
+
+	if err := Reconcile(context.TODO(), c, bs, details, "velero-ns"); err != nil {
+		t.Fatalf("Reconcile() unexpected error: %v", err)
+	}
+
+	list := &velerov1.BackupStorageLocationList{}
+	if err := c.List(context.TODO(), list, client.InNamespace("velero-ns")); err != nil {
+		t.Fatalf("failed to list BackupStorageLocations: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("expected exactly one BackupStorageLocation, got %d", len(list.Items))
+	}
+	if list.Items[0].Spec.Provider != bslProvider {
+		t.Errorf("Provider = %v, want %v", list.Items[0].Spec.Provider, bslProvider)
+	}
+}
@@ -0,0 +1,236 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// secretManagedByLabel marks Secrets that are owned and kept in sync by the
+	// openshift BlobStorageProvider, so the GC loop knows which Secrets it is
+	// allowed to reap.
+	secretManagedByLabel = "cro.integreatly.org/managed-by"
+	secretManagedByValue = "openshift-blobstorage"
+
+	// secretBlobStorageNameAnnotation and secretBlobStorageNamespaceAnnotation
+	// record which BlobStorage CR a managed Secret belongs to, so the GC loop
+	// can look the CR back up without an index.
+	secretBlobStorageNameAnnotation      = "cro.integreatly.org/blobstorage-name"
+	secretBlobStorageNamespaceAnnotation = "cro.integreatly.org/blobstorage-namespace"
+
+	defaultGCReconcileTime = time.Minute * 5
+)
+
+// SecretGC periodically sweeps Secrets created by the openshift
+// BlobStorageProvider and deletes the ones whose owning BlobStorage CR has
+// either been removed or re-pointed at a different SecretRef. It follows the
+// same shape as the garbage collector controllers in k8s.io/controller-manager:
+// an informer feeds a rate-limited workqueue, a clock makes the reconcile
+// period testable, and work is processed one key at a time.
+type SecretGC struct {
+	client         client.Client
+	secretInformer corev1informers.SecretInformer
+	queue          workqueue.RateLimitingInterface
+	clock          clock.Clock
+	logger         *logrus.Entry
+}
+
+// NewSecretGC builds a SecretGC wired up against the given Secrets informer.
+// Callers are expected to start the informer themselves; Run waits for it to
+// sync before processing any work.
+func NewSecretGC(c client.Client, secretInformer corev1informers.SecretInformer, logger *logrus.Entry) *SecretGC {
+	gc := &SecretGC{
+		client:         c,
+		secretInformer: secretInformer,
+		queue:          workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		clock:          clock.RealClock{},
+		logger:         logger.WithField("controller", "blobstorage-secret-gc"),
+	}
+
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    gc.enqueue,
+		UpdateFunc: func(_, newObj interface{}) { gc.enqueue(newObj) },
+		DeleteFunc: gc.enqueue,
+	})
+
+	return gc
+}
+
+func (gc *SecretGC) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		gc.logger.Errorf("failed to build cache key for secret: %v", err)
+		return
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if ok && secret.Labels[secretManagedByLabel] != secretManagedByValue {
+		return
+	}
+	gc.queue.Add(key)
+}
+
+// GetReconcileTime returns the period on which the GC loop should be
+// re-triggered, mirroring the provider's GetReconcileTime convention.
+func (gc *SecretGC) GetReconcileTime() time.Duration {
+	return defaultGCReconcileTime
+}
+
+// Run starts workers processing the queue until ctx is cancelled.
+func (gc *SecretGC) Run(ctx context.Context, workers int) {
+	defer gc.queue.ShutDown()
+
+	gc.logger.Info("starting blobstorage secret gc")
+	defer gc.logger.Info("stopping blobstorage secret gc")
+
+	if !cache.WaitForCacheSync(ctx.Done(), gc.secretInformer.Informer().HasSynced) {
+		return
+	}
+
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, gc.runWorker, time.Second)
+	}
+
+	go gc.runResync(ctx)
+
+	<-ctx.Done()
+}
+
+// runResync periodically re-enqueues every managed Secret the informer
+// currently knows about, on the period returned by GetReconcileTime. This
+// is what makes the GC loop self-healing rather than relying solely on the
+// informer's own AddFunc/UpdateFunc/DeleteFunc events - e.g. an isOrphaned
+// check that errored and got requeued still gets picked back up even if the
+// Secret itself never changes again.
+func (gc *SecretGC) runResync(ctx context.Context) {
+	ticker := gc.clock.NewTicker(gc.GetReconcileTime())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			gc.resync()
+		}
+	}
+}
+
+func (gc *SecretGC) resync() {
+	secrets, err := gc.secretInformer.Lister().List(labels.Everything())
+	if err != nil {
+		gc.logger.Errorf("failed to list secrets for resync: %v", err)
+		return
+	}
+	for _, secret := range secrets {
+		if secret.Labels[secretManagedByLabel] == secretManagedByValue {
+			gc.enqueue(secret)
+		}
+	}
+}
+
+func (gc *SecretGC) runWorker(ctx context.Context) {
+	for gc.processNextItem(ctx) {
+	}
+}
+
+func (gc *SecretGC) processNextItem(ctx context.Context) bool {
+	key, shutdown := gc.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer gc.queue.Done(key)
+
+	if err := gc.reconcile(ctx, key.(string)); err != nil {
+		gc.logger.Errorf("failed to reconcile secret %s: %v", key, err)
+		gc.queue.AddRateLimited(key)
+		return true
+	}
+
+	gc.queue.Forget(key)
+	return true
+}
+
+// reconcile deletes the Secret named by key if it is managed by this
+// provider and its owning BlobStorage CR no longer exists or has moved its
+// SecretRef elsewhere. Live Secrets are left untouched.
+func (gc *SecretGC) reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key %q: %w", key, err)
+	}
+
+	secret, err := gc.secretInformer.Lister().Secrets(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if secret.Labels[secretManagedByLabel] != secretManagedByValue {
+		return nil
+	}
+
+	orphaned, err := gc.isOrphaned(ctx, secret)
+	if err != nil {
+		return err
+	}
+	if !orphaned {
+		return nil
+	}
+
+	gc.logger.Infof("deleting orphaned blobstorage secret %s/%s", namespace, name)
+	if err := gc.client.Delete(ctx, secret.DeepCopy()); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (gc *SecretGC) isOrphaned(ctx context.Context, secret *corev1.Secret) (bool, error) {
+	bsName := secret.Annotations[secretBlobStorageNameAnnotation]
+	bsNamespace := secret.Annotations[secretBlobStorageNamespaceAnnotation]
+	if bsName == "" || bsNamespace == "" {
+		// Secrets predating these annotations can't be traced back to an
+		// owner, so leave them alone rather than risk deleting something live.
+		return false, nil
+	}
+
+	bs := &v1alpha1.BlobStorage{}
+	err := gc.client.Get(ctx, client.ObjectKey{Namespace: bsNamespace, Name: bsName}, bs)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if bs.Status.SecretRef == nil {
+		// The CR exists but hasn't had its SecretRef status written yet -
+		// this is the normal gap between CreateStorage creating the Secret
+		// and the outer reconciler recording it, not an orphan. Leave the
+		// Secret alone; it will be revisited once the status catches up.
+		return false, nil
+	}
+	secretNamespace := bs.Status.SecretRef.Namespace
+	if secretNamespace == "" {
+		secretNamespace = bs.Namespace
+	}
+	if bs.Status.SecretRef.Name != secret.Name || secretNamespace != secret.Namespace {
+		return true, nil
+	}
+
+	return false, nil
+}
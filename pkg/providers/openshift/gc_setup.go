@@ -0,0 +1,46 @@
+package openshift
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// secretGCWorkers is the number of workers SetupSecretGC runs the GC
+	// loop with; the workload is small enough that one is enough.
+	secretGCWorkers = 1
+
+	// secretGCInformerResync is the Secrets informer's own full resync
+	// period. It doesn't need to be tuned against GetReconcileTime: the GC
+	// loop drives its own periodic resync independently (see gc.go).
+	secretGCInformerResync = time.Hour
+)
+
+// runnableAdder is the slice of manager.Manager that SetupSecretGC needs.
+// Accepting it instead of manager.Manager directly lets tests register
+// against a lightweight stub rather than standing up a real manager.
+type runnableAdder interface {
+	Add(manager.Runnable) error
+}
+
+// SetupSecretGC builds a SecretGC backed by a Secrets informer over
+// kubeClient and registers it with mgr, so the manager starts (and stops)
+// it the same way it does every other openshift controller. This is the
+// integration point the operator's manager setup is expected to call
+// alongside the openshift BlobStorageProvider's own controller wiring.
+func SetupSecretGC(mgr runnableAdder, kubeClient kubernetes.Interface, c client.Client, logger *logrus.Entry) error {
+	factory := informers.NewSharedInformerFactory(kubeClient, secretGCInformerResync)
+	gc := NewSecretGC(c, factory.Core().V1().Secrets(), logger)
+
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		factory.Start(ctx.Done())
+		gc.Run(ctx, secretGCWorkers)
+		return nil
+	}))
+}
@@ -0,0 +1,32 @@
+package openshift
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+type stubRunnableAdder struct {
+	added []manager.Runnable
+}
+
+func (s *stubRunnableAdder) Add(r manager.Runnable) error {
+	s.added = append(s.added, r)
+	return nil
+}
+
+func TestSetupSecretGC_registersWithManager(t *testing.T) {
+	adder := &stubRunnableAdder{}
+
+	err := SetupSecretGC(adder, k8sfake.NewSimpleClientset(), fake.NewFakeClient(), logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		t.Fatalf("SetupSecretGC() unexpected error: %v", err)
+	}
+
+	if len(adder.added) != 1 {
+		t.Fatalf("SetupSecretGC() registered %d runnables with the manager, want 1", len(adder.added))
+	}
+}
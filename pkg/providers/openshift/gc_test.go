@@ -0,0 +1,152 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/informers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func managedSecret(name, namespace, bsName, bsNamespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				secretManagedByLabel: secretManagedByValue,
+			},
+			Annotations: map[string]string{
+				secretBlobStorageNameAnnotation:      bsName,
+				secretBlobStorageNamespaceAnnotation: bsNamespace,
+			},
+		},
+	}
+}
+
+func newTestSecretGC(t *testing.T, client client.Client, objects ...*corev1.Secret) (*SecretGC, func()) {
+	t.Helper()
+
+	kubeClient := k8sfake.NewSimpleClientset()
+	for _, o := range objects {
+		if _, err := kubeClient.CoreV1().Secrets(o.Namespace).Create(context.TODO(), o, v1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to seed secret: %v", err)
+		}
+	}
+
+	factory := informers.NewSharedInformerFactory(kubeClient, 0)
+	secretInformer := factory.Core().V1().Secrets()
+	// NewSecretGC requests the Secrets informer from the factory; it has to
+	// run before Start, which only starts informers already requested.
+	gc := NewSecretGC(client, secretInformer, logrus.NewEntry(logrus.StandardLogger()))
+
+	stop := make(chan struct{})
+	factory.Start(stop)
+	factory.WaitForCacheSync(stop)
+
+	return gc, func() { close(stop) }
+}
+
+func TestSecretGC_reconcile(t *testing.T) {
+	cases := []struct {
+		name       string
+		secret     *corev1.Secret
+		bs         *v1alpha1.BlobStorage
+		wantDelete bool
+	}{
+		{
+			name:   "live secret referenced by an existing BlobStorage is preserved",
+			secret: managedSecret("test-sec", "test", "test", "test"),
+			bs: &v1alpha1.BlobStorage{
+				ObjectMeta: v1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status: v1alpha1.BlobStorageStatus{
+					SecretRef: &types.SecretRef{Name: "test-sec", Namespace: "test"},
+				},
+			},
+			wantDelete: false,
+		},
+		{
+			name:       "secret whose owning BlobStorage no longer exists is removed",
+			secret:     managedSecret("orphan-sec", "test", "gone", "test"),
+			bs:         nil,
+			wantDelete: true,
+		},
+		{
+			name:   "secret for an existing BlobStorage that hasn't had its SecretRef status written yet is preserved",
+			secret: managedSecret("pending-sec", "test", "test", "test"),
+			bs: &v1alpha1.BlobStorage{
+				ObjectMeta: v1.ObjectMeta{Name: "test", Namespace: "test"},
+			},
+			wantDelete: false,
+		},
+		{
+			name:   "secret no longer referenced by its owning BlobStorage's SecretRef is removed",
+			secret: managedSecret("stale-sec", "test", "test", "test"),
+			bs: &v1alpha1.BlobStorage{
+				ObjectMeta: v1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status: v1alpha1.BlobStorageStatus{
+					SecretRef: &types.SecretRef{Name: "new-sec", Namespace: "test"},
+				},
+			},
+			wantDelete: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var initObjs []client.Object
+			if tt.bs != nil {
+				initObjs = append(initObjs, tt.bs)
+			}
+			initObjs = append(initObjs, tt.secret)
+
+			fakeClient := fake.NewFakeClient(initObjs...)
+			gc, stop := newTestSecretGC(t, fakeClient, tt.secret)
+			defer stop()
+
+			if err := gc.reconcile(context.TODO(), tt.secret.Namespace+"/"+tt.secret.Name); err != nil {
+				t.Fatalf("reconcile() unexpected error: %v", err)
+			}
+
+			got := &corev1.Secret{}
+			err := fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: tt.secret.Namespace, Name: tt.secret.Name}, got)
+			deleted := apierrors.IsNotFound(err)
+			if deleted != tt.wantDelete {
+				t.Errorf("reconcile() deleted = %v, want %v", deleted, tt.wantDelete)
+			}
+		})
+	}
+}
+
+func TestSecretGC_GetReconcileTime(t *testing.T) {
+	gc := &SecretGC{}
+	if got := gc.GetReconcileTime(); got != time.Minute*5 {
+		t.Errorf("GetReconcileTime() = %v, want %v", got, time.Minute*5)
+	}
+}
+
+func TestSecretGC_resync(t *testing.T) {
+	managed := managedSecret("managed-sec", "test", "test", "test")
+	unmanaged := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "unmanaged-sec", Namespace: "test"},
+	}
+
+	fakeClient := fake.NewFakeClient()
+	gc, stop := newTestSecretGC(t, fakeClient, managed, unmanaged)
+	defer stop()
+
+	gc.resync()
+
+	if got, want := gc.queue.Len(), 1; got != want {
+		t.Fatalf("queue.Len() after resync = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,295 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/metrics"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift/exposers/velero"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	providerName = "openshift"
+
+	// varPlaceholder is written into the output Secret for any value the
+	// openshift strategy doesn't (yet) have a real provisioner for.
+	varPlaceholder = "REPLACE ME"
+
+	defaultReconcileTime = time.Second * 10
+)
+
+// BlobStorageProvider is the `openshift` BlobStorage strategy. With no
+// backend deployment enabled it only mirrors a Secret of placeholder values
+// next to the BlobStorage CR; with one enabled (see backend.go) it
+// provisions an in-cluster S3-compatible bucket and populates the Secret
+// with real connection details.
+type BlobStorageProvider struct {
+	Client client.Client
+	Logger *logrus.Entry
+
+	// BucketProvisioner overrides how the in-cluster backend's bucket is
+	// created; nil uses the real MinIO client. Tests set this to a stub.
+	BucketProvisioner BucketProvisioner
+
+	// Recorder, if set, receives Events for BlobStorage phase transitions.
+	Recorder record.EventRecorder
+}
+
+func NewBlobStorageProvider(client client.Client, logger *logrus.Entry) *BlobStorageProvider {
+	return &BlobStorageProvider{
+		Client: client,
+		Logger: logger.WithField("provider", providerName),
+	}
+}
+
+func (p BlobStorageProvider) GetReconcileTime(bs *v1alpha1.BlobStorage) time.Duration {
+	metrics.BlobStorageReconcileTotal.WithLabelValues(providerName, providerName, "scheduled").Inc()
+	return defaultReconcileTime
+}
+
+func (p BlobStorageProvider) SupportsStrategy(s string) bool {
+	return s == providerName
+}
+
+// CreateStorage reconciles the Secret referenced by bs. When the openshift
+// strategy config opts into an in-cluster backend it is deployed first and
+// its real connection details are used to populate the Secret; otherwise
+// the Secret is filled with placeholder values, preserving whatever real
+// values are already present. If bs.Spec.ExposeAs requests it, the
+// provisioned storage is also exposed through the matching exposer (e.g. a
+// Velero BackupStorageLocation).
+//
+// Every call reports a cro_blobstorage_reconcile_total/_duration_seconds
+// sample and, when a Recorder is set, records an Event on bs for the
+// resulting phase transition.
+func (p BlobStorageProvider) CreateStorage(ctx context.Context, bs *v1alpha1.BlobStorage) (instance *providers.BlobStorageInstance, msg types.StatusMessage, err error) {
+	start := time.Now()
+	defer func() {
+		phase := "in_progress"
+		if err != nil {
+			phase = "failed"
+		} else if instance != nil {
+			phase = "complete"
+			metrics.BlobStorageLastReconcileSuccess.WithLabelValues(bs.Namespace + "/" + bs.Name).SetToCurrentTime()
+		}
+		metrics.BlobStorageReconcileTotal.WithLabelValues(providerName, providerName, phase).Inc()
+		metrics.BlobStorageReconcileDuration.WithLabelValues(providerName, providerName).Observe(time.Since(start).Seconds())
+		p.recordPhaseEvent(bs, phase, err)
+	}()
+
+	strategy, err := p.readBackendStrategy(ctx, bs)
+	if err != nil {
+		return nil, "failed to read openshift blobstorage strategy config", err
+	}
+
+	mode, err := p.resolveCredentialMode(bs, strategy)
+	if err != nil {
+		return nil, "invalid blobstorage credential mode configuration", err
+	}
+
+	if err := p.validateExposeAsCompatibility(bs, mode); err != nil {
+		return nil, "unsupported blobstorage expose-as configuration", err
+	}
+
+	if strategy.DeployBackend {
+		instance, msg, err = p.createBackendStorage(ctx, bs, strategy, mode)
+	} else {
+		instance, msg, err = p.createPlaceholderStorage(ctx, bs, strategy, mode)
+	}
+	if err != nil || instance == nil {
+		return instance, msg, err
+	}
+
+	if err := p.exposeStorage(ctx, bs, instance.DeploymentDetails); err != nil {
+		return nil, "failed to expose blobstorage", err
+	}
+
+	return instance, msg, nil
+}
+
+// recordPhaseEvent records a Kubernetes Event on bs describing the outcome
+// of a CreateStorage call. It is a no-op when no Recorder is configured, so
+// existing callers that don't wire one up keep working unchanged.
+func (p BlobStorageProvider) recordPhaseEvent(bs *v1alpha1.BlobStorage, phase string, err error) {
+	if p.Recorder == nil {
+		return
+	}
+	switch phase {
+	case "complete":
+		p.Recorder.Event(bs, corev1.EventTypeNormal, "ReconcileComplete", "blobstorage reconcile complete")
+	case "in_progress":
+		p.Recorder.Event(bs, corev1.EventTypeNormal, "ReconcileInProgress", "blobstorage reconcile in progress")
+	case "failed":
+		p.Recorder.Event(bs, corev1.EventTypeWarning, "ReconcileFailed", err.Error())
+	}
+}
+
+// validateExposeAsCompatibility rejects a BlobStorage CR's ExposeAs
+// selection that its resolved credential mode can't support, before
+// CreateStorage has deployed any backend or written the output Secret.
+// See exposeStorage for where the valid combinations are acted on.
+func (p BlobStorageProvider) validateExposeAsCompatibility(bs *v1alpha1.BlobStorage, mode string) error {
+	for _, exposeAs := range bs.Spec.ExposeAs {
+		if exposeAs == velero.ExposeAsName && mode == credentialModeSTS {
+			return fmt.Errorf("blobstorage %s/%s cannot be exposed as %q in credential mode %q: the velero exposer only supports static credentials", bs.Namespace, bs.Name, velero.ExposeAsName, credentialModeSTS)
+		}
+	}
+	return nil
+}
+
+func (p BlobStorageProvider) exposeStorage(ctx context.Context, bs *v1alpha1.BlobStorage, details *aws.BlobStorageDeploymentDetails) error {
+	for _, exposeAs := range bs.Spec.ExposeAs {
+		if exposeAs == velero.ExposeAsName {
+			if err := velero.Reconcile(ctx, p.Client, bs, details, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteStorage removes the output Secret and, when an in-cluster backend is
+// enabled, its StatefulSet, Service and bootstrap Secret as well. The STS
+// ServiceAccount and any Velero BackupStorageLocation and credentials Secret
+// created by CreateStorage are not removed.
+func (p BlobStorageProvider) DeleteStorage(ctx context.Context, bs *v1alpha1.BlobStorage) (types.StatusMessage, error) {
+	strategy, err := p.readBackendStrategy(ctx, bs)
+	if err != nil {
+		return "failed to read openshift blobstorage strategy config", err
+	}
+
+	if strategy.DeployBackend {
+		if err := p.deleteBackend(ctx, bs); err != nil {
+			return "failed to delete openshift blobstorage backend", err
+		}
+	}
+
+	name, namespace := p.secretRef(bs)
+	secret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := p.Client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return "failed to delete blobstorage secret", err
+	}
+
+	return "deleted", nil
+}
+
+func (p BlobStorageProvider) createPlaceholderStorage(ctx context.Context, bs *v1alpha1.BlobStorage, strategy *backendStrategy, mode string) (*providers.BlobStorageInstance, types.StatusMessage, error) {
+	name, namespace := p.secretRef(bs)
+
+	existing := &corev1.Secret{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, "failed to get blobstorage secret", err
+	}
+
+	data := map[string][]byte{}
+	if err == nil {
+		data = existing.Data
+	}
+
+	if mode == credentialModeSTS {
+		if err := p.reconcileServiceAccountForSTS(ctx, bs, strategy); err != nil {
+			return nil, "failed to reconcile blobstorage service account", err
+		}
+		roleARN := strategy.RoleARN
+		if roleARN == "" {
+			roleARN = p.placeholderOr(bs, data, aws.DetailsBlobStorageRoleARN)
+		}
+		details := &aws.BlobStorageDeploymentDetails{
+			BucketRegion:         p.placeholderOr(bs, data, aws.DetailsBlobStorageBucketRegion),
+			RoleARN:              roleARN,
+			WebIdentityTokenFile: defaultWebIdentityTokenFile,
+		}
+		if err := p.reconcileSecret(ctx, bs, name, namespace, stsSecretData(roleARN, details.BucketRegion)); err != nil {
+			return nil, "failed to reconcile blobstorage secret", err
+		}
+		return &providers.BlobStorageInstance{DeploymentDetails: details}, "reconcile complete", nil
+	}
+
+	details := &aws.BlobStorageDeploymentDetails{
+		BucketName:          p.placeholderOr(bs, data, aws.DetailsBlobStorageBucketName),
+		BucketRegion:        p.placeholderOr(bs, data, aws.DetailsBlobStorageBucketRegion),
+		CredentialKeyID:     p.placeholderOr(bs, data, aws.DetailsBlobStorageCredentialKeyID),
+		CredentialSecretKey: p.placeholderOr(bs, data, aws.DetailsBlobStorageCredentialSecretKey),
+	}
+
+	if err := p.reconcileSecret(ctx, bs, name, namespace, detailsToSecretData(details)); err != nil {
+		return nil, "failed to reconcile blobstorage secret", err
+	}
+
+	return &providers.BlobStorageInstance{DeploymentDetails: details}, "reconcile complete", nil
+}
+
+func (p BlobStorageProvider) secretRef(bs *v1alpha1.BlobStorage) (name, namespace string) {
+	ref := bs.Status.SecretRef
+	if ref == nil {
+		ref = bs.Spec.SecretRef
+	}
+	namespace = ref.Namespace
+	if namespace == "" {
+		namespace = bs.Namespace
+	}
+	return ref.Name, namespace
+}
+
+func (p BlobStorageProvider) reconcileSecret(ctx context.Context, bs *v1alpha1.BlobStorage, name, namespace string, data map[string][]byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				secretManagedByLabel: secretManagedByValue,
+			},
+			Annotations: map[string]string{
+				secretBlobStorageNameAnnotation:      bs.Name,
+				secretBlobStorageNamespaceAnnotation: bs.Namespace,
+			},
+		},
+		Data: data,
+	}
+
+	existing := &corev1.Secret{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		return p.Client.Create(ctx, secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.Labels = secret.Labels
+	existing.Annotations = secret.Annotations
+	existing.Data = secret.Data
+	return p.Client.Update(ctx, existing)
+}
+
+// placeholderOr returns the real value of key in data, falling back to
+// varPlaceholder and counting the fallback against
+// cro_blobstorage_secret_missing_fields when the value is missing or reset.
+func (p BlobStorageProvider) placeholderOr(bs *v1alpha1.BlobStorage, data map[string][]byte, key string) string {
+	if v, ok := data[key]; ok && len(v) > 0 {
+		return string(v)
+	}
+	metrics.BlobStorageSecretMissingFields.WithLabelValues(bs.Namespace + "/" + bs.Name).Inc()
+	return varPlaceholder
+}
+
+func detailsToSecretData(details *aws.BlobStorageDeploymentDetails) map[string][]byte {
+	return map[string][]byte{
+		aws.DetailsBlobStorageBucketName:          []byte(details.BucketName),
+		aws.DetailsBlobStorageBucketRegion:        []byte(details.BucketRegion),
+		aws.DetailsBlobStorageCredentialKeyID:     []byte(details.CredentialKeyID),
+		aws.DetailsBlobStorageCredentialSecretKey: []byte(details.CredentialSecretKey),
+	}
+}
@@ -10,13 +10,39 @@ import (
 	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1/types"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
 	v12 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
+type stubBucketProvisioner struct {
+	called bool
+	err    error
+}
+
+func (s *stubBucketProvisioner) EnsureBucket(endpoint, accessKeyID, secretAccessKey, bucketName string) error {
+	s.called = true
+	return s.err
+}
+
+func backendStrategyConfigMap(namespace string) *v12.ConfigMap {
+	return &v12.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      openshiftStrategyConfigMapName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			openshiftStrategyConfigMapKey: `{"deployBackend":true}`,
+		},
+	}
+}
+
 func TestBlobStorageProvider_CreateStorage(t *testing.T) {
 	type fields struct {
 		Client client.Client
@@ -248,3 +274,325 @@ func TestBlobStorageProvider_SupportsStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestBlobStorageProvider_CreateStorage_Backend(t *testing.T) {
+	bs := &v1alpha1.BlobStorage{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.BlobStorageSpec{
+			SecretRef: &types.SecretRef{Name: "test-sec"},
+		},
+	}
+
+	readyStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backendName(bs),
+			Namespace: bs.Namespace,
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: 1},
+	}
+
+	bootstrapSecret := &v12.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      backendBootstrapSecretName(bs),
+			Namespace: bs.Namespace,
+		},
+		Data: map[string][]byte{
+			backendAccessKeyField: []byte("access"),
+			backendSecretKeyField: []byte("secret"),
+		},
+	}
+
+	tests := []struct {
+		name         string
+		extraObjs    []client.Object
+		provisioner  *stubBucketProvisioner
+		wantInstance bool
+		wantCalled   bool
+		wantErr      bool
+	}{
+		{
+			name:         "deploys backend on first reconcile",
+			extraObjs:    []client.Object{backendStrategyConfigMap(bs.Namespace)},
+			provisioner:  &stubBucketProvisioner{},
+			wantInstance: false,
+			wantCalled:   false,
+		},
+		{
+			name: "reports InProgress until pods Ready",
+			extraObjs: []client.Object{
+				backendStrategyConfigMap(bs.Namespace),
+				&appsv1.StatefulSet{
+					ObjectMeta: v1.ObjectMeta{Name: backendName(bs), Namespace: bs.Namespace},
+					Status:     appsv1.StatefulSetStatus{ReadyReplicas: 0},
+				},
+			},
+			provisioner:  &stubBucketProvisioner{},
+			wantInstance: false,
+			wantCalled:   false,
+		},
+		{
+			name: "reuses existing deployment",
+			extraObjs: []client.Object{
+				backendStrategyConfigMap(bs.Namespace),
+				readyStatefulSet,
+				bootstrapSecret,
+			},
+			provisioner:  &stubBucketProvisioner{},
+			wantInstance: true,
+			wantCalled:   true,
+		},
+		{
+			name: "invalid pvcSize is a clean error, not a panic",
+			extraObjs: []client.Object{
+				&v12.ConfigMap{
+					ObjectMeta: v1.ObjectMeta{Name: openshiftStrategyConfigMapName, Namespace: bs.Namespace},
+					Data:       map[string]string{openshiftStrategyConfigMapKey: `{"deployBackend":true,"pvcSize":"1GB"}`},
+				},
+			},
+			provisioner:  &stubBucketProvisioner{},
+			wantInstance: false,
+			wantCalled:   false,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := BlobStorageProvider{
+				Client:            fake.NewFakeClient(tt.extraObjs...),
+				Logger:            &logrus.Entry{},
+				BucketProvisioner: tt.provisioner,
+			}
+
+			got, _, err := b.CreateStorage(context.TODO(), bs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got != nil) != tt.wantInstance {
+				t.Errorf("CreateStorage() instance = %v, wantInstance %v", got, tt.wantInstance)
+			}
+			if tt.provisioner.called != tt.wantCalled {
+				t.Errorf("EnsureBucket called = %v, want %v", tt.provisioner.called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+// TestBlobStorageProvider_CreateStorage_Backend_STSMissingRoleARN asserts
+// that createBackendStorage falls back to a placeholder RoleARN (and flags
+// cro_blobstorage_secret_missing_fields) when the strategy config selects
+// sts mode without configuring a roleArn, matching the fallback
+// createPlaceholderStorage already applies in the same situation.
+func TestBlobStorageProvider_CreateStorage_Backend_STSMissingRoleARN(t *testing.T) {
+	bs := &v1alpha1.BlobStorage{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.BlobStorageSpec{
+			SecretRef:      &types.SecretRef{Name: "test-sec"},
+			CredentialMode: "sts",
+		},
+	}
+
+	readyStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: v1.ObjectMeta{Name: backendName(bs), Namespace: bs.Namespace},
+		Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+	}
+	bootstrapSecret := &v12.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: backendBootstrapSecretName(bs), Namespace: bs.Namespace},
+		Data: map[string][]byte{
+			backendAccessKeyField: []byte("access"),
+			backendSecretKeyField: []byte("secret"),
+		},
+	}
+
+	strategyWithoutRoleARN := &v12.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: openshiftStrategyConfigMapName, Namespace: bs.Namespace},
+		Data:       map[string]string{openshiftStrategyConfigMapKey: `{"deployBackend":true}`},
+	}
+
+	b := BlobStorageProvider{
+		Client:            fake.NewFakeClient(strategyWithoutRoleARN, readyStatefulSet, bootstrapSecret),
+		Logger:            &logrus.Entry{},
+		BucketProvisioner: &stubBucketProvisioner{},
+	}
+
+	cr := bs.Namespace + "/" + bs.Name
+	missingBefore := testutil.ToFloat64(metrics.BlobStorageSecretMissingFields.WithLabelValues(cr))
+
+	got, _, err := b.CreateStorage(context.TODO(), bs)
+	if err != nil {
+		t.Fatalf("CreateStorage() unexpected error: %v", err)
+	}
+
+	if got.DeploymentDetails.RoleARN != varPlaceholder {
+		t.Errorf("expected placeholder RoleARN, got %+v", got.DeploymentDetails)
+	}
+	if missingAfter := testutil.ToFloat64(metrics.BlobStorageSecretMissingFields.WithLabelValues(cr)); missingAfter != missingBefore+1 {
+		t.Errorf("cro_blobstorage_secret_missing_fields = %v, want %v", missingAfter, missingBefore+1)
+	}
+}
+
+func credentialModeConfigMap(namespace, mode string) *v12.ConfigMap {
+	return &v12.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      openshiftStrategyConfigMapName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			openshiftStrategyConfigMapKey: `{"credentialMode":"` + mode + `","roleArn":"arn:aws:iam::123456789012:role/test"}`,
+		},
+	}
+}
+
+func TestBlobStorageProvider_CreateStorage_CredentialMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		crMode    string
+		extraObjs []client.Object
+		wantErr   bool
+		wantSTS   bool
+	}{
+		{
+			name:    "static mode is the default",
+			wantErr: false,
+			wantSTS: false,
+		},
+		{
+			name:    "sts mode can be requested on the CR",
+			crMode:  "sts",
+			wantErr: false,
+			wantSTS: true,
+		},
+		{
+			name:      "sts mode can be pinned via the strategy config",
+			extraObjs: []client.Object{credentialModeConfigMap("test", "sts")},
+			wantErr:   false,
+			wantSTS:   true,
+		},
+		{
+			name:      "conflicting CR and strategy config credential modes are rejected",
+			crMode:    "static",
+			extraObjs: []client.Object{credentialModeConfigMap("test", "sts")},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := &v1alpha1.BlobStorage{
+				ObjectMeta: v1.ObjectMeta{Name: "test", Namespace: "test"},
+				Spec: v1alpha1.BlobStorageSpec{
+					SecretRef:      &types.SecretRef{Name: "test-sec"},
+					CredentialMode: tt.crMode,
+				},
+			}
+
+			b := BlobStorageProvider{
+				Client: fake.NewFakeClient(tt.extraObjs...),
+				Logger: &logrus.Entry{},
+			}
+
+			got, _, err := b.CreateStorage(context.TODO(), bs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tt.wantSTS {
+				if got.DeploymentDetails.RoleARN == "" {
+					t.Errorf("expected RoleARN to be set in STS mode")
+				}
+				if got.DeploymentDetails.CredentialKeyID != "" || got.DeploymentDetails.CredentialSecretKey != "" {
+					t.Errorf("expected static-key fields to be empty in STS mode, got %+v", got.DeploymentDetails)
+				}
+			} else {
+				if got.DeploymentDetails.CredentialKeyID != varPlaceholder {
+					t.Errorf("expected static placeholder CredentialKeyID, got %+v", got.DeploymentDetails)
+				}
+			}
+		})
+	}
+}
+
+// TestBlobStorageProvider_CreateStorage_ExposeAsVeleroSTS asserts that
+// combining credentialModeSTS with ExposeAs: velero is rejected rather than
+// silently writing a Secret with blank static credentials into the BSL, see
+// validateExposeAsCompatibility.
+func TestBlobStorageProvider_CreateStorage_ExposeAsVeleroSTS(t *testing.T) {
+	bs := &v1alpha1.BlobStorage{
+		ObjectMeta: v1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: v1alpha1.BlobStorageSpec{
+			SecretRef:      &types.SecretRef{Name: "test-sec"},
+			CredentialMode: "sts",
+			ExposeAs:       []string{"velero"},
+		},
+	}
+
+	fakeClient := fake.NewFakeClient()
+	b := BlobStorageProvider{
+		Client: fakeClient,
+		Logger: &logrus.Entry{},
+	}
+
+	if _, _, err := b.CreateStorage(context.TODO(), bs); err == nil {
+		t.Fatal("CreateStorage() expected error for sts credential mode combined with ExposeAs: velero, got nil")
+	}
+
+	secret := &v12.Secret{}
+	err := fakeClient.Get(context.TODO(), client.ObjectKey{Name: "test-sec", Namespace: "test"}, secret)
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("CreateStorage() should reject before writing the output secret, got secret lookup error = %v", err)
+	}
+}
+
+// TestBlobStorageProvider_CreateStorage_Metrics exercises the same "missing
+// secret values are reset" scenario as TestBlobStorageProvider_CreateStorage
+// but asserts the Prometheus side effects instead of the returned instance.
+func TestBlobStorageProvider_CreateStorage_Metrics(t *testing.T) {
+	bs := &v1alpha1.BlobStorage{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "metrics-test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.BlobStorageSpec{
+			SecretRef: &types.SecretRef{Name: "test-sec"},
+		},
+		Status: v1alpha1.BlobStorageStatus{
+			Phase:     types.PhaseComplete,
+			SecretRef: &types.SecretRef{Name: "metrics-test-sec", Namespace: "test"},
+		},
+	}
+
+	client := fake.NewFakeClient(&v12.Secret{
+		ObjectMeta: v1.ObjectMeta{Namespace: "test", Name: "metrics-test-sec"},
+		Data: map[string][]byte{
+			aws.DetailsBlobStorageCredentialKeyID: []byte("test"),
+		},
+	})
+
+	cr := bs.Namespace + "/" + bs.Name
+	missingBefore := testutil.ToFloat64(metrics.BlobStorageSecretMissingFields.WithLabelValues(cr))
+	completeBefore := testutil.ToFloat64(metrics.BlobStorageReconcileTotal.WithLabelValues(providerName, providerName, "complete"))
+
+	b := BlobStorageProvider{Client: client, Logger: &logrus.Entry{}}
+	if _, _, err := b.CreateStorage(context.TODO(), bs); err != nil {
+		t.Fatalf("CreateStorage() unexpected error: %v", err)
+	}
+
+	missingAfter := testutil.ToFloat64(metrics.BlobStorageSecretMissingFields.WithLabelValues(cr))
+	if missingAfter-missingBefore != 3 {
+		t.Errorf("cro_blobstorage_secret_missing_fields delta = %v, want 3", missingAfter-missingBefore)
+	}
+
+	completeAfter := testutil.ToFloat64(metrics.BlobStorageReconcileTotal.WithLabelValues(providerName, providerName, "complete"))
+	if completeAfter-completeBefore != 1 {
+		t.Errorf("cro_blobstorage_reconcile_total{phase=complete} delta = %v, want 1", completeAfter-completeBefore)
+	}
+}